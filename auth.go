@@ -0,0 +1,310 @@
+// SPDX-License-Identifier: MIT
+// Copyright © 2026 doxx.net. All Rights Reserved.
+
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// watchSIGHUP calls reload every time the process receives SIGHUP, so
+// credential files can be rotated without restarting the server.
+func watchSIGHUP(reload func()) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go func() {
+		for range sighup {
+			reload()
+		}
+	}()
+}
+
+// Scopes gate which endpoints a set of credentials may use.
+const (
+	ScopeStream = "stream"
+	ScopeTail   = "tail"
+	ScopeLogs   = "logs"
+	ScopeAdmin  = "admin"
+)
+
+// Auth validates a request's credentials for a given scope, in the spirit
+// of astraproxy's Auth.Validate. Server.authorize wraps a chain of these
+// and writes the actual HTTP error response if none of them grant access.
+type Auth interface {
+	Validate(r *http.Request, scope string) bool
+}
+
+// bearerToken extracts the credential from "Authorization: Bearer <token>",
+// falling back to the legacy ?secret= query param so existing phone/dev
+// tooling keeps working. Preferring the header keeps secrets out of access
+// logs and browser history.
+func bearerToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if token, ok := strings.CutPrefix(auth, "Bearer "); ok {
+			return token
+		}
+	}
+	return r.URL.Query().Get("secret")
+}
+
+// constantTimeEqual compares two strings without leaking timing
+// information about how many leading bytes matched.
+func constantTimeEqual(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
+
+// AuthChain tries each Auth in order and grants the request if any of them
+// accepts its credentials for the required scope.
+type AuthChain []Auth
+
+func (chain AuthChain) Validate(r *http.Request, scope string) bool {
+	for _, auth := range chain {
+		if auth.Validate(r, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// SharedSecretAuth is the original single global secret, now read from the
+// Authorization header first (see bearerToken) with constant-time
+// comparison.
+type SharedSecretAuth struct {
+	Secret string
+}
+
+func (a SharedSecretAuth) Validate(r *http.Request, scope string) bool {
+	return a.Secret != "" && constantTimeEqual(bearerToken(r), a.Secret)
+}
+
+// HtpasswdAuth authenticates bearer tokens against an htpasswd-style file
+// of bcrypt hashes (one "id:bcryptHash" pair per line, id a non-secret
+// label for the operator's own bookkeeping), hot-reloaded on SIGHUP so
+// tokens can be rotated without a restart. The file never contains a
+// usable credential in cleartext: Validate bcrypt-compares the presented
+// token against every stored hash rather than looking it up by the token
+// itself.
+type HtpasswdAuth struct {
+	path string
+
+	mu     sync.RWMutex
+	hashes [][]byte // bcrypt hashes, ids discarded after parsing
+}
+
+// LoadHtpasswdAuth reads path into a new HtpasswdAuth. It does not watch
+// for SIGHUP itself; the caller wires Reload to the signal (see watchSIGHUP).
+func LoadHtpasswdAuth(path string) (*HtpasswdAuth, error) {
+	a := &HtpasswdAuth{path: path}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Reload re-reads the htpasswd file from disk, replacing the in-memory
+// hash set. Safe to call from a SIGHUP handler.
+func (a *HtpasswdAuth) Reload() error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return fmt.Errorf("open auth file: %w", err)
+	}
+	defer f.Close()
+
+	var hashes [][]byte
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		_, hash, ok := strings.Cut(line, ":")
+		if !ok {
+			return fmt.Errorf("auth file: malformed line %q", line)
+		}
+		hashes = append(hashes, []byte(hash))
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.hashes = hashes
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *HtpasswdAuth) Validate(r *http.Request, scope string) bool {
+	token := bearerToken(r)
+	if token == "" {
+		return false
+	}
+
+	a.mu.RLock()
+	hashes := a.hashes
+	a.mu.RUnlock()
+
+	for _, hash := range hashes {
+		if bcrypt.CompareHashAndPassword(hash, []byte(token)) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// issuedToken is one bearer token minted via POST /admin/tokens.
+type issuedToken struct {
+	Scopes map[string]bool
+	Expiry time.Time // zero means no expiry
+}
+
+func (t issuedToken) expired() bool {
+	return !t.Expiry.IsZero() && time.Now().After(t.Expiry)
+}
+
+func (t issuedToken) hasScope(scope string) bool {
+	return len(t.Scopes) == 0 || t.Scopes[scope]
+}
+
+// BearerTokenAuth is an in-memory registry of per-device bearer tokens,
+// minted and revoked through /admin/tokens.
+type BearerTokenAuth struct {
+	mu     sync.RWMutex
+	tokens map[string]issuedToken
+}
+
+func NewBearerTokenAuth() *BearerTokenAuth {
+	return &BearerTokenAuth{tokens: make(map[string]issuedToken)}
+}
+
+func (a *BearerTokenAuth) Validate(r *http.Request, scope string) bool {
+	token := bearerToken(r)
+	if token == "" {
+		return false
+	}
+
+	a.mu.RLock()
+	issued, ok := a.tokens[token]
+	a.mu.RUnlock()
+
+	return ok && !issued.expired() && issued.hasScope(scope)
+}
+
+// Issue mints a new random bearer token scoped to scopes, optionally
+// expiring after ttl (zero ttl means it never expires).
+func (a *BearerTokenAuth) Issue(scopes []string, ttl time.Duration) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	token := hex.EncodeToString(raw)
+
+	issued := issuedToken{}
+	if len(scopes) > 0 {
+		issued.Scopes = make(map[string]bool, len(scopes))
+		for _, scope := range scopes {
+			issued.Scopes[scope] = true
+		}
+	}
+	if ttl > 0 {
+		issued.Expiry = time.Now().Add(ttl)
+	}
+
+	a.mu.Lock()
+	a.tokens[token] = issued
+	a.mu.Unlock()
+
+	return token, nil
+}
+
+// Revoke removes a token immediately.
+func (a *BearerTokenAuth) Revoke(token string) {
+	a.mu.Lock()
+	delete(a.tokens, token)
+	a.mu.Unlock()
+}
+
+// adminTokenRequest is the POST /admin/tokens request body.
+type adminTokenRequest struct {
+	Scopes []string `json:"scopes"`
+	TTL    string   `json:"ttl,omitempty"` // e.g. "24h"; omitted/empty means no expiry
+}
+
+// adminTokenRevokeRequest is the DELETE /admin/tokens request body. The
+// token is carried in the body rather than the URL path so it never lands
+// in access logs or proxy/browser history, the same reasoning bearerToken
+// prefers the Authorization header over ?secret=.
+type adminTokenRevokeRequest struct {
+	Token string `json:"token"`
+}
+
+// handleAdminTokens issues (POST) or revokes (DELETE) bearer tokens. It
+// requires the admin scope itself, so only already-trusted callers can mint
+// new credentials.
+func (s *Server) handleAdminTokens(w http.ResponseWriter, r *http.Request) {
+	if !s.authorize(w, r, ScopeAdmin) {
+		return
+	}
+
+	if s.bearerAuth == nil {
+		http.Error(w, "Bearer token issuance is not enabled", http.StatusNotImplemented)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		var req adminTokenRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		var ttl time.Duration
+		if req.TTL != "" {
+			var err error
+			ttl, err = time.ParseDuration(req.TTL)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Invalid ttl: %v", err), http.StatusBadRequest)
+				return
+			}
+		}
+
+		token, err := s.bearerAuth.Issue(req.Scopes, ttl)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"token":  token,
+			"scopes": req.Scopes,
+			"ttl":    req.TTL,
+		})
+
+	case http.MethodDelete:
+		var req adminTokenRevokeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Token == "" {
+			http.Error(w, "Missing token in request body", http.StatusBadRequest)
+			return
+		}
+		s.bearerAuth.Revoke(req.Token)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
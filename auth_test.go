@@ -0,0 +1,157 @@
+// SPDX-License-Identifier: MIT
+// Copyright © 2026 doxx.net. All Rights Reserved.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func authRequest(token string) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+	return r
+}
+
+func writeHtpasswdFile(t *testing.T, tokens ...string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create htpasswd file: %v", err)
+	}
+	defer f.Close()
+
+	for i, token := range tokens {
+		hash, err := bcrypt.GenerateFromPassword([]byte(token), bcrypt.MinCost)
+		if err != nil {
+			t.Fatalf("hash token: %v", err)
+		}
+		fmt.Fprintf(f, "user%d:%s\n", i, hash)
+	}
+	return path
+}
+
+func TestHtpasswdAuthValidatesAgainstStoredHashesOnly(t *testing.T) {
+	path := writeHtpasswdFile(t, "correct-token")
+
+	a, err := LoadHtpasswdAuth(path)
+	if err != nil {
+		t.Fatalf("LoadHtpasswdAuth: %v", err)
+	}
+
+	if !a.Validate(authRequest("correct-token"), ScopeStream) {
+		t.Error("Validate(correct-token) = false, want true")
+	}
+	if a.Validate(authRequest("wrong-token"), ScopeStream) {
+		t.Error("Validate(wrong-token) = true, want false")
+	}
+	if a.Validate(authRequest(""), ScopeStream) {
+		t.Error("Validate(\"\") = true, want false")
+	}
+}
+
+func TestHtpasswdAuthReloadRejectsMalformedLine(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	if err := os.WriteFile(path, []byte("not-a-valid-line-without-a-colon\n"), 0o644); err != nil {
+		t.Fatalf("write htpasswd file: %v", err)
+	}
+
+	if _, err := LoadHtpasswdAuth(path); err == nil {
+		t.Error("LoadHtpasswdAuth with malformed line: want error, got nil")
+	}
+}
+
+func TestHtpasswdAuthReloadPicksUpRotatedFile(t *testing.T) {
+	path := writeHtpasswdFile(t, "old-token")
+	a, err := LoadHtpasswdAuth(path)
+	if err != nil {
+		t.Fatalf("LoadHtpasswdAuth: %v", err)
+	}
+
+	rotated := writeHtpasswdFile(t, "new-token")
+	if err := os.Rename(rotated, path); err != nil {
+		t.Fatalf("rename rotated file: %v", err)
+	}
+	if err := a.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if a.Validate(authRequest("old-token"), ScopeStream) {
+		t.Error("old-token still validates after rotation")
+	}
+	if !a.Validate(authRequest("new-token"), ScopeStream) {
+		t.Error("new-token does not validate after rotation")
+	}
+}
+
+func TestBearerTokenAuthScopes(t *testing.T) {
+	a := NewBearerTokenAuth()
+
+	token, err := a.Issue([]string{ScopeTail}, 0)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+
+	if !a.Validate(authRequest(token), ScopeTail) {
+		t.Error("scoped token failed Validate for its own scope")
+	}
+	if a.Validate(authRequest(token), ScopeAdmin) {
+		t.Error("scoped token passed Validate for a scope it wasn't issued")
+	}
+
+	unscoped, err := a.Issue(nil, 0)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if !a.Validate(authRequest(unscoped), ScopeAdmin) {
+		t.Error("unscoped token should validate for every scope")
+	}
+}
+
+func TestBearerTokenAuthExpiry(t *testing.T) {
+	a := NewBearerTokenAuth()
+
+	token, err := a.Issue(nil, time.Hour)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if !a.Validate(authRequest(token), ScopeStream) {
+		t.Error("unexpired token failed Validate")
+	}
+
+	// Force expiry directly rather than sleeping, since the token map is
+	// unexported and this test lives in the same package.
+	a.mu.Lock()
+	a.tokens[token] = issuedToken{Expiry: time.Now().Add(-time.Minute)}
+	a.mu.Unlock()
+
+	if a.Validate(authRequest(token), ScopeStream) {
+		t.Error("expired token still validates")
+	}
+}
+
+func TestBearerTokenAuthRevoke(t *testing.T) {
+	a := NewBearerTokenAuth()
+
+	token, err := a.Issue(nil, 0)
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	a.Revoke(token)
+
+	if a.Validate(authRequest(token), ScopeStream) {
+		t.Error("revoked token still validates")
+	}
+}
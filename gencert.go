@@ -13,8 +13,10 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"encoding/base32"
 	"encoding/base64"
 	"encoding/pem"
 	"fmt"
@@ -25,6 +27,11 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "client" {
+		genClientCert(os.Args[2:])
+		return
+	}
+
 	hostname := "debugsocket"
 	if len(os.Args) > 1 {
 		hostname = os.Args[1]
@@ -124,3 +131,90 @@ func main() {
 	fmt.Printf("Certificate valid: %s to %s\n", notBefore.Format("2006-01-02"), notAfter.Format("2006-01-02"))
 	fmt.Printf("Hostname/SNI: %s (also valid for localhost, 127.0.0.1, ::1)\n", hostname)
 }
+
+// genClientCert generates a self-signed mTLS client certificate for a phone
+// and prints the DeviceHash that DevSocket will derive from it, formatted
+// ready to paste into an --authorized-devices file.
+//
+// Run with: go run gencert.go client <device-name>
+func genClientCert(args []string) {
+	name := "phone"
+	if len(args) > 0 {
+		name = args[0]
+	}
+
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate private key: %v\n", err)
+		os.Exit(1)
+	}
+
+	notBefore := time.Now()
+	notAfter := notBefore.Add(10 * 365 * 24 * time.Hour)
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate serial number: %v\n", err)
+		os.Exit(1)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			CommonName:   name,
+			Organization: []string{"doxx.net DebugSocket Client"},
+		},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create certificate: %v\n", err)
+		os.Exit(1)
+	}
+
+	certFile, err := os.Create(name + ".crt")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create cert file: %v\n", err)
+		os.Exit(1)
+	}
+	pem.Encode(certFile, &pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
+	certFile.Close()
+
+	keyFile, err := os.Create(name + ".key")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create key file: %v\n", err)
+		os.Exit(1)
+	}
+	keyBytes, err := x509.MarshalECPrivateKey(privateKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to marshal private key: %v\n", err)
+		os.Exit(1)
+	}
+	pem.Encode(keyFile, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+	keyFile.Close()
+
+	// DeviceHash mirrors identity.go's DeviceHash(leaf cert DER) — duplicated
+	// here since this file builds standalone (go:build ignore) and can't
+	// import the main package's sources.
+	sum := sha256.Sum256(derBytes)
+	deviceID := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(sum[:])
+
+	fmt.Println("✅ Generated client certificate")
+	fmt.Println("")
+	fmt.Println("Files created:")
+	fmt.Printf("  📄 %s.crt  (PEM client certificate)\n", name)
+	fmt.Printf("  🔑 %s.key  (PEM private key)\n", name)
+	fmt.Println("")
+	fmt.Printf("Device ID: %s\n", deviceID)
+	fmt.Println("")
+	fmt.Println("Add to --authorized-devices file:")
+	fmt.Printf("  %s %s\n", deviceID, name)
+	fmt.Println("")
+	fmt.Println("Phone connects with this cert presented via mTLS, no ?device= needed:")
+	fmt.Printf("  wss://HOST/stream --cert %s.crt --key %s.key\n", name, name)
+}
@@ -0,0 +1,130 @@
+// SPDX-License-Identifier: MIT
+// Copyright © 2026 doxx.net. All Rights Reserved.
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base32"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// deviceIDEncoding mirrors Syncthing's unpadded base32 device ID formatting
+// so IDs are easy to read aloud and paste without ambiguous characters.
+var deviceIDEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// DeviceHash derives a stable device identity from a client certificate's
+// DER-encoded leaf, analogous to Syncthing's NewDeviceID(cert.Certificate[0]).
+// Because it only hashes the presented leaf, it works with self-signed
+// client certificates as long as the server only demands that some
+// certificate be presented (tls.RequireAnyClientCert) and checks the
+// resulting ID against an allow-list.
+func DeviceHash(leafDER []byte) string {
+	sum := sha256.Sum256(leafDER)
+	return deviceIDEncoding.EncodeToString(sum[:])
+}
+
+// deviceHashFromConnState extracts the DeviceHash of the first client
+// certificate presented on a TLS connection, or "" if none was presented.
+func deviceHashFromConnState(state *tls.ConnectionState) string {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return ""
+	}
+	return DeviceHash(state.PeerCertificates[0].Raw)
+}
+
+// DeviceACL is one entry from the --authorized-devices file: a device ID
+// mapped to a friendly name and the set of scopes it may use.
+type DeviceACL struct {
+	ID     string
+	Name   string
+	Scopes map[string]bool
+}
+
+// HasScope reports whether this device is authorized for the given scope.
+// An entry with no scopes listed is authorized for everything, matching the
+// common case of a single trusted personal device.
+func (d DeviceACL) HasScope(scope string) bool {
+	if len(d.Scopes) == 0 {
+		return true
+	}
+	return d.Scopes[scope]
+}
+
+// AuthorizedDevices is the in-memory, file-backed allow-list of device IDs
+// permitted to use /stream. Only handleStream consults it, for the "stream"
+// scope; the other scopes are parsed and stored but no endpoint checks them.
+type AuthorizedDevices struct {
+	mu      sync.RWMutex
+	path    string
+	devices map[string]DeviceACL
+}
+
+// LoadAuthorizedDevices parses the --authorized-devices file. Each
+// non-empty, non-comment line is:
+//
+//	<device-id> <friendly-name> [scope,scope,...]
+//
+// Scopes are a subset of "stream", "tail", "logs", "admin"; omitting them
+// authorizes the device for everything.
+func LoadAuthorizedDevices(path string) (*AuthorizedDevices, error) {
+	a := &AuthorizedDevices{path: path}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// Reload re-reads the authorized-devices file from disk, replacing the
+// in-memory allow-list. Safe to call from a SIGHUP handler.
+func (a *AuthorizedDevices) Reload() error {
+	f, err := os.Open(a.path)
+	if err != nil {
+		return fmt.Errorf("open authorized-devices file: %w", err)
+	}
+	defer f.Close()
+
+	devices := make(map[string]DeviceACL)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return fmt.Errorf("authorized-devices: malformed line %q", line)
+		}
+
+		acl := DeviceACL{ID: fields[0], Name: fields[1]}
+		if len(fields) >= 3 {
+			acl.Scopes = make(map[string]bool)
+			for _, scope := range strings.Split(fields[2], ",") {
+				acl.Scopes[scope] = true
+			}
+		}
+		devices[acl.ID] = acl
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	a.devices = devices
+	a.mu.Unlock()
+	return nil
+}
+
+// Lookup returns the ACL for a device ID, if authorized.
+func (a *AuthorizedDevices) Lookup(id string) (DeviceACL, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	acl, ok := a.devices[id]
+	return acl, ok
+}
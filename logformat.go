@@ -0,0 +1,142 @@
+// SPDX-License-Identifier: MIT
+// Copyright © 2026 doxx.net. All Rights Reserved.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// severityRank orders levels from most to least verbose so "?level=warn"
+// matches warn and anything more severe (warn, error), the way most log
+// viewers treat a minimum-severity filter.
+var severityRank = map[string]int{
+	"trace": 0,
+	"debug": 1,
+	"info":  2,
+	"warn":  3,
+	"error": 4,
+}
+
+// logFilter is the set of server-side filters accepted by /logs/{device} and
+// /tail/{device}: ?level=warn&subsystem=vpn&field.user_id=42
+type logFilter struct {
+	minLevel  string
+	subsystem string
+	fields    map[string]string
+}
+
+// parseLogFilter extracts a logFilter from request query params.
+func parseLogFilter(r *http.Request) logFilter {
+	f := logFilter{
+		minLevel:  r.URL.Query().Get("level"),
+		subsystem: r.URL.Query().Get("subsystem"),
+	}
+
+	for key, values := range r.URL.Query() {
+		if strings.HasPrefix(key, "field.") && len(values) > 0 {
+			if f.fields == nil {
+				f.fields = make(map[string]string)
+			}
+			f.fields[strings.TrimPrefix(key, "field.")] = values[0]
+		}
+	}
+
+	return f
+}
+
+// isZero reports whether the filter would accept every entry.
+func (f logFilter) isZero() bool {
+	return f.minLevel == "" && f.subsystem == "" && len(f.fields) == 0
+}
+
+// Match reports whether entry satisfies the filter.
+func (f logFilter) Match(entry LogEntry) bool {
+	if f.minLevel != "" {
+		want, ok := severityRank[strings.ToLower(f.minLevel)]
+		if !ok {
+			return false
+		}
+		got, ok := severityRank[strings.ToLower(entry.Level)]
+		if !ok || got < want {
+			return false
+		}
+	}
+
+	if f.subsystem != "" && entry.Subsystem != f.subsystem {
+		return false
+	}
+
+	for key, want := range f.fields {
+		got, ok := entry.Fields[key]
+		if !ok || fmtField(got) != want {
+			return false
+		}
+	}
+
+	return true
+}
+
+// filterEntries returns the subset of entries matching f.
+func filterEntries(entries []LogEntry, f logFilter) []LogEntry {
+	if f.isZero() {
+		return entries
+	}
+	filtered := make([]LogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if f.Match(entry) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered
+}
+
+// fmtField renders an arbitrary decoded JSON/CBOR field value as a string
+// for comparison against a query parameter, which always arrives as text.
+func fmtField(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case nil:
+		return ""
+	default:
+		b, err := json.Marshal(val)
+		if err != nil {
+			return ""
+		}
+		return strings.Trim(string(b), `"`)
+	}
+}
+
+// decodeLogEntry parses a single /stream message according to the
+// negotiated wire format. "cbor" is a compact binary framing for
+// high-volume producers where JSON parsing dominates CPU; "json" (the
+// default) keeps the original text/JSON behavior, including the raw-string
+// fallback for messages that aren't JSON at all.
+func decodeLogEntry(format string, message []byte) LogEntry {
+	var entry LogEntry
+
+	var err error
+	if format == "cbor" {
+		err = cbor.Unmarshal(message, &entry)
+	} else {
+		err = json.Unmarshal(message, &entry)
+	}
+
+	if err != nil {
+		// Not parseable in the negotiated format - treat as a raw message,
+		// matching the original behavior for plain-text JSON producers.
+		entry = LogEntry{Message: string(message)}
+	}
+
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+
+	return entry
+}
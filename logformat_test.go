@@ -0,0 +1,112 @@
+// SPDX-License-Identifier: MIT
+// Copyright © 2026 doxx.net. All Rights Reserved.
+
+package main
+
+import "testing"
+
+func TestLogFilterMatchMinLevel(t *testing.T) {
+	f := logFilter{minLevel: "warn"}
+
+	cases := []struct {
+		level string
+		want  bool
+	}{
+		{"error", true},
+		{"warn", true},
+		{"info", false},
+		{"debug", false},
+		{"WARN", true}, // case-insensitive
+		{"not-a-level", false},
+	}
+
+	for _, c := range cases {
+		got := f.Match(LogEntry{Level: c.level})
+		if got != c.want {
+			t.Errorf("Match(level=%q) with minLevel=warn = %v, want %v", c.level, got, c.want)
+		}
+	}
+}
+
+func TestLogFilterMatchSubsystem(t *testing.T) {
+	f := logFilter{subsystem: "vpn"}
+
+	if !f.Match(LogEntry{Subsystem: "vpn"}) {
+		t.Error("Match with matching subsystem = false, want true")
+	}
+	if f.Match(LogEntry{Subsystem: "auth"}) {
+		t.Error("Match with non-matching subsystem = true, want false")
+	}
+}
+
+func TestLogFilterMatchFields(t *testing.T) {
+	f := logFilter{fields: map[string]string{"user_id": "42"}}
+
+	if !f.Match(LogEntry{Fields: map[string]any{"user_id": float64(42)}}) {
+		t.Error("Match with matching numeric field = false, want true")
+	}
+	if f.Match(LogEntry{Fields: map[string]any{"user_id": float64(7)}}) {
+		t.Error("Match with non-matching field value = true, want false")
+	}
+	if f.Match(LogEntry{Fields: map[string]any{}}) {
+		t.Error("Match with missing field = true, want false")
+	}
+}
+
+func TestLogFilterIsZero(t *testing.T) {
+	if !(logFilter{}).isZero() {
+		t.Error("empty logFilter.isZero() = false, want true")
+	}
+	if (logFilter{minLevel: "warn"}).isZero() {
+		t.Error("logFilter with minLevel set isZero() = true, want false")
+	}
+}
+
+func TestFilterEntriesAppliesAllCriteria(t *testing.T) {
+	f := logFilter{minLevel: "warn", subsystem: "vpn"}
+	entries := []LogEntry{
+		{Level: "error", Subsystem: "vpn", Message: "keep"},
+		{Level: "info", Subsystem: "vpn", Message: "drop-level"},
+		{Level: "error", Subsystem: "auth", Message: "drop-subsystem"},
+	}
+
+	got := filterEntries(entries, f)
+	if len(got) != 1 || got[0].Message != "keep" {
+		t.Fatalf("filterEntries = %v, want only the \"keep\" entry", got)
+	}
+}
+
+func TestFmtField(t *testing.T) {
+	cases := []struct {
+		in   any
+		want string
+	}{
+		{"already-a-string", "already-a-string"},
+		{nil, ""},
+		{float64(42), "42"},
+		{true, "true"},
+	}
+
+	for _, c := range cases {
+		if got := fmtField(c.in); got != c.want {
+			t.Errorf("fmtField(%v) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestDecodeLogEntryFallsBackToRawMessage(t *testing.T) {
+	entry := decodeLogEntry("json", []byte("not valid json"))
+	if entry.Message != "not valid json" {
+		t.Fatalf("decodeLogEntry fallback = %q, want raw message passed through", entry.Message)
+	}
+	if entry.Timestamp.IsZero() {
+		t.Error("decodeLogEntry left Timestamp zero, want it defaulted to now")
+	}
+}
+
+func TestDecodeLogEntryParsesJSON(t *testing.T) {
+	entry := decodeLogEntry("json", []byte(`{"msg":"hello","level":"error"}`))
+	if entry.Message != "hello" || entry.Level != "error" {
+		t.Fatalf("decodeLogEntry parsed = %+v, want Message=hello Level=error", entry)
+	}
+}
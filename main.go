@@ -11,33 +11,53 @@ import (
 	"log"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
 )
 
-// LogEntry represents a single log message from a device
+// LogEntry represents a single log message from a device. Level, Subsystem,
+// Location and Fields are optional; a producer that only sends {ts, msg}
+// (or a bare non-JSON string) keeps working unchanged.
 type LogEntry struct {
-	Timestamp time.Time `json:"ts"`
-	Message   string    `json:"msg"`
+	Timestamp time.Time      `json:"ts"`
+	Seq       uint64         `json:"seq"` // assigned by the device's log ring on ingest; see ?since_seq on /logs/{device}
+	Message   string         `json:"msg"`
+	Level     string         `json:"level,omitempty"`     // trace, debug, info, warn, error
+	Subsystem string         `json:"subsystem,omitempty"` // e.g. "vpn", "auth"
+	Location  string         `json:"file,omitempty"`      // "file.go:123"
+	Fields    map[string]any `json:"fields,omitempty"`
 }
 
 // Session represents a connected device's debug session
 type Session struct {
-	DeviceHash string            `json:"device"`
-	Name       string            `json:"name"`
-	IPv4       string            `json:"ipv4,omitempty"`
-	IPv6       string            `json:"ipv6,omitempty"`
-	Connected  time.Time         `json:"connected"`
-	Logs       []LogEntry        `json:"-"` // Not serialized in device list
-	LogCount   int               `json:"log_count"`
-	conn       *websocket.Conn   // Producer connection (phone)
-	connMu     sync.Mutex        // Protects conn writes
-	tailConns  []*websocket.Conn // Consumer connections (dev clients watching tail)
-	tailMu     sync.RWMutex      // Protects tailConns
-	logMu      sync.RWMutex      // Protects Logs slice
+	DeviceHash string          `json:"device"`
+	Name       string          `json:"name"`
+	IPv4       string          `json:"ipv4,omitempty"`
+	IPv6       string          `json:"ipv6,omitempty"`
+	Connected  time.Time       `json:"connected"`
+	logs       *logRing        // fixed-capacity, sequence-numbered ring of recent entries
+	conn       *websocket.Conn // Producer connection (phone)
+	connMu     sync.Mutex      // Protects conn writes
+	wireFormat string          // "json" (default) or "cbor", negotiated via ?fmt= on /stream
+	tailConns  []*tailConsumer // Consumer connections (dev clients watching tail)
+	tailMu     sync.RWMutex    // Protects tailConns
+
+	lastLogAt   atomic.Value // time.Time of the most recently ingested entry, for health staleness
+	approxBytes atomic.Int64 // running estimate of in-memory log ring size, for health memory reporting
+}
+
+// tailConsumer pairs a tail WebSocket connection with the filter it
+// negotiated via query params and the bounded channel broadcastToTail
+// enqueues onto, so one slow dev client can't stall the ingest goroutine.
+type tailConsumer struct {
+	conn   *websocket.Conn
+	filter logFilter
+	msgs   chan []byte
 }
 
 // Server handles WebSocket connections and log storage
@@ -45,10 +65,52 @@ type Server struct {
 	sessions   map[string]*Session // deviceHash -> Session
 	sessionsMu sync.RWMutex
 	upgrader   websocket.Upgrader
-	secret     string
+	auth       AuthChain        // tried in order; any provider granting the scope admits the request
+	bearerAuth *BearerTokenAuth // nil unless --enable-tokens; also present in auth chain when set
+	logStore   *FileLogStore    // optional on-disk persistence, nil if --log-dir unset
+
+	requireClientCert bool               // derive device identity from mTLS client cert instead of ?device=
+	authorizedDevices *AuthorizedDevices // nil means any presented client cert is accepted
+
+	metrics      *Metrics // nil disables /metrics and all collector updates
+	metricsToken string   // separate credential for scraping /metrics
+
+	maxLogsPerDevice int            // capacity of each device's in-memory log ring
+	tailBufferSize   int            // per-consumer channel capacity in broadcastToTail
+	tailDropPolicy   tailDropPolicy // what to do when a consumer's channel is full
+
+	tailDropped atomic.Int64 // total log entries dropped instead of delivered to a slow tail consumer
+
+	deviceSeq sync.Map // deviceHash -> highest LogEntry.Seq appended, survives a device reconnecting mid-process
+}
+
+// ServerConfig bundles the optional knobs NewServer accepts; only Auth is
+// required. Left at its zero value, each other field either disables the
+// corresponding feature or falls back to the default noted on the field.
+type ServerConfig struct {
+	Auth              AuthChain
+	BearerAuth        *BearerTokenAuth
+	LogStore          *FileLogStore
+	RequireClientCert bool
+	AuthorizedDevices *AuthorizedDevices
+	Metrics           *Metrics
+	MetricsToken      string
+
+	MaxLogsPerDevice int            // capacity of each device's in-memory log ring; defaults to 100000
+	TailBufferSize   int            // per-consumer channel capacity; defaults to 256
+	TailDropPolicy   tailDropPolicy // defaults to tailDropOldest
 }
 
-func NewServer(secret string) *Server {
+func NewServer(cfg ServerConfig) *Server {
+	maxLogsPerDevice := cfg.MaxLogsPerDevice
+	if maxLogsPerDevice <= 0 {
+		maxLogsPerDevice = 100_000
+	}
+	tailBufferSize := cfg.TailBufferSize
+	if tailBufferSize <= 0 {
+		tailBufferSize = 256
+	}
+
 	return &Server{
 		sessions: make(map[string]*Session),
 		upgrader: websocket.Upgrader{
@@ -56,29 +118,73 @@ func NewServer(secret string) *Server {
 				return true // Accept all origins for debug tool
 			},
 		},
-		secret: secret,
+		auth:              cfg.Auth,
+		bearerAuth:        cfg.BearerAuth,
+		logStore:          cfg.LogStore,
+		requireClientCert: cfg.RequireClientCert,
+		authorizedDevices: cfg.AuthorizedDevices,
+		metrics:           cfg.Metrics,
+		metricsToken:      cfg.MetricsToken,
+		maxLogsPerDevice:  maxLogsPerDevice,
+		tailBufferSize:    tailBufferSize,
+		tailDropPolicy:    cfg.TailDropPolicy,
 	}
 }
 
-// checkSecret validates the shared secret from query params
-func (s *Server) checkSecret(r *http.Request) bool {
-	return r.URL.Query().Get("secret") == s.secret
+// authorize checks the request against the server's auth chain for scope,
+// writing a 401 response and returning false if none of the configured
+// providers accept it.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request, scope string) bool {
+	if s.auth.Validate(r, scope) {
+		return true
+	}
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	return false
 }
 
 // handleStream handles WebSocket connections from phones (log producers)
 func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
-	if !s.checkSecret(r) {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
-		return
-	}
+	var deviceHash, deviceName string
 
-	deviceHash := r.URL.Query().Get("device")
-	if deviceHash == "" {
-		http.Error(w, "Missing device parameter", http.StatusBadRequest)
-		return
+	if s.requireClientCert {
+		deviceHash = deviceHashFromConnState(r.TLS)
+		if deviceHash == "" {
+			http.Error(w, "Client certificate required", http.StatusUnauthorized)
+			return
+		}
+
+		acl, authorized := (DeviceACL{}), true
+		if s.authorizedDevices != nil {
+			acl, authorized = s.authorizedDevices.Lookup(deviceHash)
+		}
+		if !authorized || !acl.HasScope("stream") {
+			log.Printf("[STREAM] Rejected unauthorized device %s from %s", deviceHash[:min(16, len(deviceHash))], r.RemoteAddr)
+			http.Error(w, "Device not authorized", http.StatusForbidden)
+			return
+		}
+
+		deviceName = acl.Name
+		if deviceName == "" {
+			deviceName = r.URL.Query().Get("name")
+		}
+	} else {
+		if !s.authorize(w, r, ScopeStream) {
+			return
+		}
+
+		deviceHash = r.URL.Query().Get("device")
+		if deviceHash == "" {
+			http.Error(w, "Missing device parameter", http.StatusBadRequest)
+			return
+		}
+		if strings.ContainsAny(deviceHash, `/\`) || strings.Contains(deviceHash, "..") {
+			http.Error(w, "Invalid device parameter", http.StatusBadRequest)
+			return
+		}
+
+		deviceName = r.URL.Query().Get("name")
 	}
 
-	deviceName := r.URL.Query().Get("name")
 	if deviceName == "" {
 		deviceName = "Unknown Device"
 	}
@@ -86,6 +192,11 @@ func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
 	ipv4 := r.URL.Query().Get("ipv4")
 	ipv6 := r.URL.Query().Get("ipv6")
 
+	wireFormat := r.URL.Query().Get("fmt")
+	if wireFormat == "" {
+		wireFormat = "json"
+	}
+
 	conn, err := s.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Printf("[STREAM] Failed to upgrade connection from %s: %v", r.RemoteAddr, err)
@@ -109,13 +220,35 @@ func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
 		IPv4:       ipv4,
 		IPv6:       ipv6,
 		Connected:  time.Now(),
-		Logs:       make([]LogEntry, 0, 1000), // Pre-allocate
+		logs:       newLogRing(s.maxLogsPerDevice),
 		conn:       conn,
-		tailConns:  make([]*websocket.Conn, 0),
+		wireFormat: wireFormat,
+		tailConns:  make([]*tailConsumer, 0),
+	}
+
+	// Continue this device's sequence numbering across the reconnect
+	// rather than restarting the ring at 0, so a dev client polling
+	// ?since_seq=N doesn't silently see an empty result until the new
+	// ring produces N fresh entries. Prefer the in-process record of the
+	// last sequence seen; fall back to on-disk history for a device's
+	// first connection after a server restart.
+	if last, ok := s.deviceSeq.Load(deviceHash); ok {
+		session.logs.SeedSeq(last.(uint64) + 1)
+	} else if s.logStore != nil {
+		if last, err := s.logStore.LastSeq(deviceHash); err != nil {
+			log.Printf("[STREAM] Failed to read last sequence for %s: %v", deviceHash[:min(16, len(deviceHash))], err)
+		} else if last > 0 {
+			session.logs.SeedSeq(last + 1)
+		}
 	}
+
 	s.sessions[deviceHash] = session
 	s.sessionsMu.Unlock()
 
+	if s.metrics != nil {
+		s.metrics.SessionsActive.Inc()
+	}
+
 	log.Printf("[STREAM] 📱 Device connected: %s (%s) from %s", deviceHash[:min(16, len(deviceHash))], deviceName, r.RemoteAddr)
 	if ipv4 != "" || ipv6 != "" {
 		log.Printf("[STREAM]    IPv4: %s, IPv6: %s", ipv4, ipv6)
@@ -124,6 +257,10 @@ func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
 	// Handle incoming log messages
 	defer func() {
 		conn.Close()
+		if s.metrics != nil {
+			s.metrics.SessionsActive.Dec()
+			s.metrics.ForgetDevice(deviceHash)
+		}
 		log.Printf("[STREAM] 📱 Device disconnected: %s (%s)", deviceHash[:min(16, len(deviceHash))], deviceName)
 	}()
 
@@ -136,34 +273,49 @@ func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
 			break
 		}
 
-		// Parse log entry
-		var entry LogEntry
-		if err := json.Unmarshal(message, &entry); err != nil {
-			// If not JSON, treat as raw message
-			entry = LogEntry{
-				Timestamp: time.Now(),
-				Message:   string(message),
+		// Parse log entry, in whichever wire format was negotiated
+		entry := decodeLogEntry(session.wireFormat, message)
+
+		// Store in the device's ring buffer, which stamps entry.Seq and
+		// evicts the oldest entry once the ring is full.
+		entry = session.logs.Append(entry)
+		s.deviceSeq.Store(deviceHash, entry.Seq)
+
+		session.lastLogAt.Store(entry.Timestamp)
+		session.approxBytes.Add(int64(len(entry.Message) + len(entry.Level) + len(entry.Subsystem) + len(entry.Location) + 64))
+
+		if s.metrics != nil {
+			level := entry.Level
+			if level == "" {
+				level = "unknown"
 			}
+			s.metrics.LogsIngested.WithLabelValues(deviceHash, level).Inc()
 		}
 
-		// If timestamp is zero, set to now
-		if entry.Timestamp.IsZero() {
-			entry.Timestamp = time.Now()
+		// Persist to disk if a log store is configured
+		if s.logStore != nil {
+			if err := s.logStore.Append(deviceHash, entry); err != nil {
+				log.Printf("[STREAM] Failed to persist log entry for %s: %v", deviceHash[:min(16, len(deviceHash))], err)
+			}
 		}
 
-		// Store log entry
-		session.logMu.Lock()
-		session.Logs = append(session.Logs, entry)
-		session.LogCount = len(session.Logs)
-		session.logMu.Unlock()
-
 		// Forward to tail consumers
 		s.broadcastToTail(session, entry)
 	}
 }
 
-// broadcastToTail sends a log entry to all tail WebSocket consumers
+// broadcastToTail enqueues a log entry onto every tail consumer's bounded
+// channel whose negotiated filter matches it. It never performs a
+// WebSocket write itself - a slow or stalled dev client is handled by
+// enqueueTail's drop policy, not by blocking the ingest goroutine that
+// holds session.tailMu here. Consumer removal on disconnect happens in
+// handleTail, once its pumpTailConsumer goroutine or read loop notices.
 func (s *Server) broadcastToTail(session *Session, entry LogEntry) {
+	if s.metrics != nil {
+		start := time.Now()
+		defer func() { s.metrics.BroadcastLatency.Observe(time.Since(start).Seconds()) }()
+	}
+
 	session.tailMu.RLock()
 	defer session.tailMu.RUnlock()
 
@@ -173,36 +325,17 @@ func (s *Server) broadcastToTail(session *Session, entry LogEntry) {
 
 	msg, _ := json.Marshal(entry)
 
-	var deadConns []*websocket.Conn
-	for _, conn := range session.tailConns {
-		conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
-		if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
-			deadConns = append(deadConns, conn)
+	for _, consumer := range session.tailConns {
+		if !consumer.filter.Match(entry) {
+			continue
 		}
-	}
-
-	// Clean up dead connections (upgrade lock)
-	if len(deadConns) > 0 {
-		session.tailMu.RUnlock()
-		session.tailMu.Lock()
-		for _, dead := range deadConns {
-			for i, conn := range session.tailConns {
-				if conn == dead {
-					session.tailConns = append(session.tailConns[:i], session.tailConns[i+1:]...)
-					conn.Close()
-					break
-				}
-			}
-		}
-		session.tailMu.Unlock()
-		session.tailMu.RLock()
+		s.enqueueTail(session, consumer, msg)
 	}
 }
 
 // handleTail handles WebSocket connections from dev clients (log consumers)
 func (s *Server) handleTail(w http.ResponseWriter, r *http.Request) {
-	if !s.checkSecret(r) {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	if !s.authorize(w, r, ScopeTail) {
 		return
 	}
 
@@ -230,24 +363,69 @@ func (s *Server) handleTail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Add to tail consumers
+	filter := parseLogFilter(r)
+
+	// Add to tail consumers before reading any replay history, so entries
+	// ingested while the replay snapshot is being read/sent queue up on
+	// consumer.msgs instead of vanishing - broadcastToTail only needs the
+	// consumer to be registered to enqueue for it, it doesn't need
+	// pumpTailConsumer running yet. Writes go through this bounded channel
+	// so one slow dev client can never block broadcastToTail for the rest
+	// of the device's tail audience.
+	consumer := &tailConsumer{conn: conn, filter: filter, msgs: make(chan []byte, s.tailBufferSize)}
 	session.tailMu.Lock()
-	session.tailConns = append(session.tailConns, conn)
+	session.tailConns = append(session.tailConns, consumer)
 	session.tailMu.Unlock()
 
+	if s.metrics != nil {
+		s.metrics.TailConsumers.WithLabelValues(deviceHash).Inc()
+	}
+
 	log.Printf("[TAIL] 👀 Dev client connected to %s (%s) from %s", deviceHash[:min(16, len(deviceHash))], session.Name, r.RemoteAddr)
 
+	// Stream on-disk replay history before draining any live entries
+	// queued above, so a consumer joining mid-session doesn't miss
+	// context. This write is synchronous and is the only writer of conn
+	// until pumpTailConsumer starts just below, so there's no concurrent
+	// write race on the WebSocket.
+	if replayStr := r.URL.Query().Get("replay"); replayStr != "" && s.logStore != nil {
+		duration, err := parseDuration(replayStr)
+		if err != nil {
+			log.Printf("[TAIL] Invalid replay parameter %q: %v", replayStr, err)
+		} else {
+			history, err := s.logStore.Replay(deviceHash, time.Now().Add(-duration))
+			if err != nil {
+				log.Printf("[TAIL] Replay failed for %s: %v", deviceHash[:min(16, len(deviceHash))], err)
+			}
+			for _, entry := range filterEntries(history, filter) {
+				msg, _ := json.Marshal(entry)
+				conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+				if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+					break
+				}
+			}
+		}
+	}
+
+	// Only now start draining consumer.msgs - this picks up, in order,
+	// anything ingested during replay followed by new live entries.
+	go s.pumpTailConsumer(consumer)
+
 	// Keep connection alive - just read and discard (wait for close)
 	defer func() {
 		session.tailMu.Lock()
 		for i, c := range session.tailConns {
-			if c == conn {
+			if c == consumer {
 				session.tailConns = append(session.tailConns[:i], session.tailConns[i+1:]...)
 				break
 			}
 		}
 		session.tailMu.Unlock()
+		close(consumer.msgs)
 		conn.Close()
+		if s.metrics != nil {
+			s.metrics.TailConsumers.WithLabelValues(deviceHash).Dec()
+		}
 		log.Printf("[TAIL] 👀 Dev client disconnected from %s", deviceHash[:min(16, len(deviceHash))])
 	}()
 
@@ -260,24 +438,21 @@ func (s *Server) handleTail(w http.ResponseWriter, r *http.Request) {
 
 // handleDevices returns list of connected devices
 func (s *Server) handleDevices(w http.ResponseWriter, r *http.Request) {
-	if !s.checkSecret(r) {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	if !s.authorize(w, r, ScopeAdmin) {
 		return
 	}
 
 	s.sessionsMu.RLock()
 	devices := make([]map[string]interface{}, 0, len(s.sessions))
 	for _, session := range s.sessions {
-		session.logMu.RLock()
 		devices = append(devices, map[string]interface{}{
 			"device":    session.DeviceHash,
 			"name":      session.Name,
 			"ipv4":      session.IPv4,
 			"ipv6":      session.IPv6,
 			"connected": session.Connected.Format(time.RFC3339),
-			"log_count": len(session.Logs),
+			"log_count": session.logs.Len(),
 		})
-		session.logMu.RUnlock()
 	}
 	s.sessionsMu.RUnlock()
 
@@ -287,8 +462,7 @@ func (s *Server) handleDevices(w http.ResponseWriter, r *http.Request) {
 
 // handleLogs returns logs for a device with optional filtering
 func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
-	if !s.checkSecret(r) {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	if !s.authorize(w, r, ScopeLogs) {
 		return
 	}
 
@@ -313,12 +487,31 @@ func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
 	// Get filter parameters
 	regexPattern := r.URL.Query().Get("regex")
 	sinceStr := r.URL.Query().Get("since")
+	sinceSeqStr := r.URL.Query().Get("since_seq")
+
+	var logs []LogEntry
+	if sinceSeqStr != "" {
+		sinceSeq, err := strconv.ParseUint(sinceSeqStr, 10, 64)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("Invalid since_seq parameter: %v", err), http.StatusBadRequest)
+			return
+		}
+		logs = session.logs.Since(sinceSeq)
+	} else {
+		logs = session.logs.All()
+	}
 
-	// Copy logs for filtering
-	session.logMu.RLock()
-	logs := make([]LogEntry, len(session.Logs))
-	copy(logs, session.Logs)
-	session.logMu.RUnlock()
+	// Merge in on-disk history that predates the in-memory buffer. Skipped
+	// when since_seq is set: a resuming client already has everything it
+	// saw before that sequence number and only wants what's new.
+	if s.logStore != nil && sinceSeqStr == "" {
+		history, err := s.logStore.Replay(deviceHash, time.Time{})
+		if err != nil {
+			log.Printf("[LOGS] Replay failed for %s: %v", deviceHash[:min(16, len(deviceHash))], err)
+		} else {
+			logs = mergeLogs(history, logs)
+		}
+	}
 
 	// Filter by time (since parameter: 5m, 1h, 30s, etc.)
 	if sinceStr != "" {
@@ -353,6 +546,9 @@ func (s *Server) handleLogs(w http.ResponseWriter, r *http.Request) {
 		logs = filtered
 	}
 
+	// Filter by level/subsystem/fields
+	logs = filterEntries(logs, parseLogFilter(r))
+
 	// Check format parameter
 	format := r.URL.Query().Get("format")
 	if format == "text" {
@@ -374,25 +570,43 @@ func parseDuration(s string) (time.Duration, error) {
 
 // handleHealth returns health check
 func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	if !s.checkSecret(r) {
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	if !s.authorize(w, r, ScopeAdmin) {
 		return
 	}
 
 	s.sessionsMu.RLock()
+	devices := make(map[string]interface{}, len(s.sessions))
+	var totalLogBytes int64
+	for hash, session := range s.sessions {
+		var staleFor string
+		if last, ok := session.lastLogAt.Load().(time.Time); ok {
+			staleFor = time.Since(last).Round(time.Second).String()
+		}
+		bytes := session.approxBytes.Load()
+		totalLogBytes += bytes
+		devices[hash] = map[string]interface{}{
+			"name":            session.Name,
+			"log_count":       session.logs.Len(),
+			"stale_for":       staleFor,
+			"in_memory_bytes": bytes,
+		}
+	}
 	deviceCount := len(s.sessions)
 	s.sessionsMu.RUnlock()
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"status":  "ok",
-		"devices": deviceCount,
-		"ts":      time.Now().Format(time.RFC3339),
+		"status":              "ok",
+		"devices":             deviceCount,
+		"device_detail":       devices,
+		"in_memory_log_bytes": totalLogBytes,
+		"dropped_messages":    s.tailDropped.Load(),
+		"ts":                  time.Now().Format(time.RFC3339),
 	})
 }
 
 // startHTTPServer starts an HTTP/HTTPS server on the given address
-func startHTTPServer(addr string, useTLS bool, certFile, keyFile string, handler http.Handler, wg *sync.WaitGroup) {
+func startHTTPServer(addr string, useTLS bool, certFile, keyFile string, requireClientCert bool, handler http.Handler, wg *sync.WaitGroup) {
 	defer wg.Done()
 
 	if useTLS {
@@ -406,6 +620,13 @@ func startHTTPServer(addr string, useTLS bool, certFile, keyFile string, handler
 			MinVersion:   tls.VersionTLS12,
 		}
 
+		if requireClientCert {
+			// We only require that a certificate be presented, not that it
+			// chains to a trusted CA: devices use self-signed certs and are
+			// authorized by the DeviceHash of their leaf, Syncthing-style.
+			tlsConfig.ClientAuth = tls.RequireAnyClientCert
+		}
+
 		httpServer := &http.Server{
 			Addr:      addr,
 			Handler:   handler,
@@ -434,21 +655,111 @@ func main() {
 	certFile := flag.String("cert", "/etc/certs/pki/2025-2026/doxx.net.crt", "Path to TLS certificate")
 	keyFile := flag.String("key", "/etc/certs/pki/2025-2026/doxx.net.key", "Path to TLS private key")
 
-	// Auth
-	secret := flag.String("secret", "", "Shared secret for authentication (required)")
+	// Auth - at least one of --secret, --auth-file, --enable-tokens is required
+	secret := flag.String("secret", "", "Shared secret for authentication")
+	authFile := flag.String("auth-file", "", "htpasswd-style file of \"id:bcryptHash\" lines, hot-reloaded on SIGHUP")
+	enableTokens := flag.Bool("enable-tokens", false, "Accept bearer tokens issued via POST /admin/tokens")
+
+	// mTLS device identity
+	requireClientCert := flag.Bool("require-client-cert", false, "Require an mTLS client certificate and derive device identity from it instead of ?device=")
+	authorizedDevicesFile := flag.String("authorized-devices", "", "Path to a file mapping device IDs to friendly names and scopes (required with --require-client-cert)")
+
+	// Persistent log storage
+	logDir := flag.String("log-dir", "", "Directory for persistent on-disk log storage (disabled if empty)")
+	logMaxSize := flag.String("log-max-size", "100MB", "Rotate a device's log file once it exceeds this size (e.g. 100MB, 1GB)")
+	logRetention := flag.Duration("log-retention", 7*24*time.Hour, "Delete on-disk log files older than this")
+
+	// Metrics
+	metricsToken := flag.String("metrics-token", "", "Bearer token required to scrape /metrics (disabled if empty)")
+
+	// In-memory log buffering and tail backpressure
+	maxLogsPerDevice := flag.Int("max-logs-per-device", 100_000, "Maximum in-memory log entries retained per device (oldest are overwritten)")
+	tailBuffer := flag.Int("tail-buffer", 256, "Per-consumer channel capacity for /tail/{device} before --tail-drop kicks in")
+	tailDrop := flag.String("tail-drop", "oldest", "Policy when a tail consumer falls behind: oldest, newest, or disconnect")
 
 	flag.Parse()
 
 	// Validate required flags
-	if *secret == "" {
-		log.Fatal("--secret is required")
+	if *secret == "" && *authFile == "" && !*enableTokens {
+		log.Fatal("At least one of --secret, --auth-file, or --enable-tokens is required")
 	}
 
 	if *bindV4 == "" && *bindV6 == "" {
 		log.Fatal("At least one of --bind-v4 or --bind-v6 is required")
 	}
 
-	server := NewServer(*secret)
+	if *requireClientCert && !*useTLS {
+		log.Fatal("--require-client-cert requires --tls")
+	}
+
+	tailDropPolicy, err := parseTailDropPolicy(*tailDrop)
+	if err != nil {
+		log.Fatalf("Invalid --tail-drop: %v", err)
+	}
+
+	var authorizedDevices *AuthorizedDevices
+	if *authorizedDevicesFile != "" {
+		var err error
+		authorizedDevices, err = LoadAuthorizedDevices(*authorizedDevicesFile)
+		if err != nil {
+			log.Fatalf("Failed to load --authorized-devices: %v", err)
+		}
+	} else if *requireClientCert {
+		log.Fatal("--require-client-cert requires --authorized-devices")
+	}
+
+	var logStore *FileLogStore
+	if *logDir != "" {
+		maxSize, err := parseSize(*logMaxSize)
+		if err != nil {
+			log.Fatalf("Invalid --log-max-size: %v", err)
+		}
+		logStore, err = NewFileLogStore(*logDir, maxSize, *logRetention)
+		if err != nil {
+			log.Fatalf("Failed to initialize log store: %v", err)
+		}
+	}
+
+	var authChain AuthChain
+	if *secret != "" {
+		authChain = append(authChain, SharedSecretAuth{Secret: *secret})
+	}
+
+	var htpasswdAuth *HtpasswdAuth
+	if *authFile != "" {
+		var err error
+		htpasswdAuth, err = LoadHtpasswdAuth(*authFile)
+		if err != nil {
+			log.Fatalf("Failed to load --auth-file: %v", err)
+		}
+		authChain = append(authChain, htpasswdAuth)
+		watchSIGHUP(func() {
+			if err := htpasswdAuth.Reload(); err != nil {
+				log.Printf("[AUTH] Failed to reload --auth-file: %v", err)
+			} else {
+				log.Printf("[AUTH] Reloaded --auth-file")
+			}
+		})
+	}
+
+	var bearerAuth *BearerTokenAuth
+	if *enableTokens {
+		bearerAuth = NewBearerTokenAuth()
+		authChain = append(authChain, bearerAuth)
+	}
+
+	server := NewServer(ServerConfig{
+		Auth:              authChain,
+		BearerAuth:        bearerAuth,
+		LogStore:          logStore,
+		RequireClientCert: *requireClientCert,
+		AuthorizedDevices: authorizedDevices,
+		Metrics:           NewMetrics(),
+		MetricsToken:      *metricsToken,
+		MaxLogsPerDevice:  *maxLogsPerDevice,
+		TailBufferSize:    *tailBuffer,
+		TailDropPolicy:    tailDropPolicy,
+	})
 
 	// WebSocket endpoints
 	http.HandleFunc("/stream", server.handleStream) // Phone connects here
@@ -457,13 +768,23 @@ func main() {
 	// REST endpoints
 	http.HandleFunc("/devices", server.handleDevices) // List devices
 	http.HandleFunc("/logs/", server.handleLogs)      // Get/filter logs
+	http.HandleFunc("/admin/tokens", server.handleAdminTokens)
+	http.HandleFunc("/metrics", server.handleMetrics) // Prometheus scrape endpoint
 
 	// Health check (no auth)
 	http.HandleFunc("/health", server.handleHealth)
 
 	// Print startup info
 	log.Printf("🔌 DebugSocket starting...")
-	log.Printf("   Secret: %s...", (*secret)[:min(8, len(*secret))])
+	if *secret != "" {
+		log.Printf("   Auth: shared secret (%s...)", (*secret)[:min(8, len(*secret))])
+	}
+	if *authFile != "" {
+		log.Printf("   Auth: htpasswd file %s", *authFile)
+	}
+	if *enableTokens {
+		log.Printf("   Auth: bearer tokens via POST /admin/tokens")
+	}
 	if *useTLS {
 		log.Printf("   TLS: enabled")
 		log.Printf("   Cert: %s", *certFile)
@@ -471,13 +792,32 @@ func main() {
 	} else {
 		log.Printf("   TLS: disabled")
 	}
+	if logStore != nil {
+		log.Printf("   Log storage: %s (max %s/file, retention %s)", *logDir, *logMaxSize, *logRetention)
+	} else {
+		log.Printf("   Log storage: in-memory only (pass --log-dir to persist)")
+	}
+	if *requireClientCert {
+		log.Printf("   Device identity: mTLS client certificate (%s)", *authorizedDevicesFile)
+	} else {
+		log.Printf("   Device identity: ?device= query parameter")
+	}
+	if *metricsToken != "" {
+		log.Printf("   Metrics: enabled on /metrics")
+	} else {
+		log.Printf("   Metrics: disabled (pass --metrics-token to enable /metrics)")
+	}
+	log.Printf("   Log buffer: %d entries/device, tail buffer %d msgs/consumer, drop policy %q", *maxLogsPerDevice, *tailBuffer, *tailDrop)
 	log.Printf("")
+	log.Printf("   Credentials are read from \"Authorization: Bearer <token>\" (preferred) or ?secret= (legacy)")
 	log.Printf("   Endpoints:")
-	log.Printf("   📱 Phone:   ws[s]://HOST/stream?device=X&name=Y&secret=Z")
-	log.Printf("   👀 Tail:    ws[s]://HOST/tail/{device}?secret=Z")
-	log.Printf("   📋 Devices: GET /devices?secret=Z")
-	log.Printf("   📄 Logs:    GET /logs/{device}?secret=Z[&since=5m][&regex=X][&format=text]")
-	log.Printf("   ❤️  Health:  GET /health?secret=Z")
+	log.Printf("   📱 Phone:   ws[s]://HOST/stream?device=X&name=Y[&fmt=cbor]")
+	log.Printf("   👀 Tail:    ws[s]://HOST/tail/{device}[?replay=1h][&level=warn&subsystem=vpn&field.user_id=42]")
+	log.Printf("   📋 Devices: GET /devices")
+	log.Printf("   📄 Logs:    GET /logs/{device}[?since=5m|since_seq=N][&regex=X][&format=text][&level=warn&subsystem=vpn&field.user_id=42]")
+	log.Printf("   ❤️  Health:  GET /health")
+	log.Printf("   🔑 Tokens:  POST/DELETE /admin/tokens (requires admin scope)")
+	log.Printf("   📈 Metrics: GET /metrics (Authorization: Bearer <metrics-token>)")
 	log.Printf("")
 
 	var wg sync.WaitGroup
@@ -485,13 +825,13 @@ func main() {
 	// Start IPv4 server if specified
 	if *bindV4 != "" {
 		wg.Add(1)
-		go startHTTPServer(*bindV4, *useTLS, *certFile, *keyFile, nil, &wg)
+		go startHTTPServer(*bindV4, *useTLS, *certFile, *keyFile, *requireClientCert, nil, &wg)
 	}
 
 	// Start IPv6 server if specified
 	if *bindV6 != "" {
 		wg.Add(1)
-		go startHTTPServer(*bindV6, *useTLS, *certFile, *keyFile, nil, &wg)
+		go startHTTPServer(*bindV6, *useTLS, *certFile, *keyFile, *requireClientCert, nil, &wg)
 	}
 
 	// Wait for servers to exit
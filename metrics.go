@@ -0,0 +1,104 @@
+// SPDX-License-Identifier: MIT
+// Copyright © 2026 doxx.net. All Rights Reserved.
+
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Metrics holds the Prometheus collectors DevSocket exposes on /metrics,
+// alongside the standard process/Go runtime collectors.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	SessionsActive   prometheus.Gauge
+	LogsIngested     *prometheus.CounterVec // labels: device, level
+	TailConsumers    *prometheus.GaugeVec   // label: device
+	WSWriteErrors    prometheus.Counter
+	BroadcastLatency prometheus.Histogram
+	TailDropped      *prometheus.CounterVec // labels: device, reason
+}
+
+// NewMetrics creates and registers all DevSocket collectors on a fresh
+// registry, along with the default process and Go runtime collectors.
+func NewMetrics() *Metrics {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}))
+	registry.MustRegister(prometheus.NewGoCollector())
+
+	m := &Metrics{
+		registry: registry,
+		SessionsActive: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "devsocket_sessions_active",
+			Help: "Number of devices currently connected to /stream.",
+		}),
+		LogsIngested: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "devsocket_logs_ingested_total",
+			Help: "Total log entries ingested from devices.",
+		}, []string{"device", "level"}),
+		TailConsumers: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "devsocket_tail_consumers",
+			Help: "Number of dev clients currently tailing a device.",
+		}, []string{"device"}),
+		WSWriteErrors: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "devsocket_ws_write_errors_total",
+			Help: "Total WebSocket write errors while broadcasting to tail consumers.",
+		}),
+		BroadcastLatency: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "devsocket_broadcast_latency_seconds",
+			Help:    "Time spent broadcasting one log entry to all tail consumers of a device.",
+			Buckets: prometheus.DefBuckets,
+		}),
+		TailDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "devsocket_tail_dropped_total",
+			Help: "Total log entries dropped instead of delivered to a slow tail consumer.",
+		}, []string{"device", "reason"}),
+	}
+
+	registry.MustRegister(
+		m.SessionsActive,
+		m.LogsIngested,
+		m.TailConsumers,
+		m.WSWriteErrors,
+		m.BroadcastLatency,
+		m.TailDropped,
+	)
+
+	return m
+}
+
+// Handler returns the promhttp handler for scraping this registry.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ForgetDevice removes every label series keyed by deviceHash from the
+// device-labeled collectors. Without this, a process that runs long enough
+// to see many distinct devices (every reconnect with a new ?device= value
+// in the non-mTLS case) would accumulate one permanent series per device
+// forever, even after it disconnects for good.
+func (m *Metrics) ForgetDevice(deviceHash string) {
+	m.LogsIngested.DeletePartialMatch(prometheus.Labels{"device": deviceHash})
+	m.TailConsumers.DeleteLabelValues(deviceHash)
+	m.TailDropped.DeletePartialMatch(prometheus.Labels{"device": deviceHash})
+}
+
+// handleMetrics serves /metrics, gated by a separate --metrics-token rather
+// than the main Auth chain so a scraper can be provisioned independently of
+// device/dev-client credentials.
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	if s.metrics == nil || s.metricsToken == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(bearerToken(r)), []byte(s.metricsToken)) != 1 {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	s.metrics.Handler().ServeHTTP(w, r)
+}
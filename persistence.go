@@ -0,0 +1,395 @@
+// SPDX-License-Identifier: MIT
+// Copyright © 2026 doxx.net. All Rights Reserved.
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileLogStore persists LogEntry records to per-device ndjson files under
+// baseDir, rotating by size and pruning by age so a long-running server
+// doesn't accumulate unbounded disk usage.
+//
+// Layout: {baseDir}/{deviceDir}/{date}.ndjson, rolling to
+// {baseDir}/{deviceDir}/{date}.{gen}.ndjson once a file exceeds maxSize,
+// where deviceDir is deviceDirName(deviceHash) rather than deviceHash
+// itself - deviceHash is only a hash (and thus already path-safe) when
+// --require-client-cert is set, but in the default mode it's the
+// caller-supplied ?device= value and can't be trusted as a path component.
+type FileLogStore struct {
+	baseDir   string
+	maxSize   int64
+	retention time.Duration
+
+	mu      sync.Mutex
+	writers map[string]*deviceWriter // deviceHash -> current open file
+}
+
+// deviceWriter tracks the file currently being appended to for one device.
+type deviceWriter struct {
+	file *os.File
+	date string
+	gen  int
+	size int64
+}
+
+// NewFileLogStore creates a FileLogStore rooted at baseDir, creating the
+// directory if needed, and starts a background goroutine that prunes files
+// older than retention once per hour.
+func NewFileLogStore(baseDir string, maxSize int64, retention time.Duration) (*FileLogStore, error) {
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create log dir: %w", err)
+	}
+
+	store := &FileLogStore{
+		baseDir:   baseDir,
+		maxSize:   maxSize,
+		retention: retention,
+		writers:   make(map[string]*deviceWriter),
+	}
+
+	if retention > 0 {
+		go store.retentionLoop()
+	}
+
+	return store, nil
+}
+
+// Append writes entry as a single ndjson line to the device's current log
+// file, rotating to a new generation when maxSize would be exceeded.
+func (s *FileLogStore) Append(deviceHash string, entry LogEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	w, err := s.writerFor(deviceHash, entry.Timestamp)
+	if err != nil {
+		return err
+	}
+
+	if s.maxSize > 0 && w.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(deviceHash, w); err != nil {
+			return err
+		}
+		w = s.writers[deviceHash]
+	}
+
+	n, err := w.file.Write(line)
+	w.size += int64(n)
+	return err
+}
+
+// writerFor returns the open writer for deviceHash, opening (or rolling to)
+// the file for ts's date if the device has no writer yet or the date has
+// changed.
+func (s *FileLogStore) writerFor(deviceHash string, ts time.Time) (*deviceWriter, error) {
+	date := ts.Format("2006-01-02")
+	w := s.writers[deviceHash]
+	if w != nil && w.date == date {
+		return w, nil
+	}
+	if w != nil {
+		w.file.Close()
+	}
+	return s.openGeneration(deviceHash, date, 0)
+}
+
+// rotate closes the current file for deviceHash and opens the next
+// generation for the same date.
+func (s *FileLogStore) rotate(deviceHash string, w *deviceWriter) error {
+	w.file.Close()
+	next, err := s.openGeneration(deviceHash, w.date, w.gen+1)
+	if err != nil {
+		return err
+	}
+	s.writers[deviceHash] = next
+	return nil
+}
+
+func (s *FileLogStore) openGeneration(deviceHash, date string, gen int) (*deviceWriter, error) {
+	dir := filepath.Join(s.baseDir, deviceDirName(deviceHash))
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create device log dir: %w", err)
+	}
+
+	f, err := os.OpenFile(filepath.Join(dir, logFileName(date, gen)), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open log file: %w", err)
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	w := &deviceWriter{file: f, date: date, gen: gen, size: info.Size()}
+	s.writers[deviceHash] = w
+	return w, nil
+}
+
+// deviceDirName maps a deviceHash to a fixed-charset directory name safe to
+// join under baseDir. deviceHash is attacker-controlled in the default,
+// non-mTLS mode (see handleStream), so a value like "../../etc/passwd" must
+// not be able to escape baseDir - hashing it, the same way DeviceHash
+// derives an ID from a client certificate, makes that structurally
+// impossible rather than relying on blocklisting specific substrings.
+func deviceDirName(deviceHash string) string {
+	sum := sha256.Sum256([]byte(deviceHash))
+	return deviceIDEncoding.EncodeToString(sum[:])
+}
+
+func logFileName(date string, gen int) string {
+	if gen == 0 {
+		return date + ".ndjson"
+	}
+	return fmt.Sprintf("%s.%d.ndjson", date, gen)
+}
+
+// Replay reads every on-disk log entry for deviceHash newer than since,
+// across all rotated generations and dates, in chronological order.
+func (s *FileLogStore) Replay(deviceHash string, since time.Time) ([]LogEntry, error) {
+	dir := filepath.Join(s.baseDir, deviceDirName(deviceHash))
+	names, err := sortedLogFiles(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var entries []LogEntry
+	for _, name := range names {
+		lines, err := readLogFile(filepath.Join(dir, name))
+		if err != nil {
+			log.Printf("[LOGSTORE] Failed to read %s: %v", name, err)
+			continue
+		}
+		for _, entry := range lines {
+			if entry.Timestamp.After(since) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Timestamp.Before(entries[j].Timestamp)
+	})
+
+	return entries, nil
+}
+
+// LastSeq returns the highest LogEntry.Seq persisted for deviceHash, or 0
+// if nothing has been persisted yet. Only the most recently written file
+// needs scanning since sequence numbers are assigned in increasing order,
+// so this is cheap even for a device with a long history.
+func (s *FileLogStore) LastSeq(deviceHash string) (uint64, error) {
+	dir := filepath.Join(s.baseDir, deviceDirName(deviceHash))
+	names, err := sortedLogFiles(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if len(names) == 0 {
+		return 0, nil
+	}
+
+	entries, err := readLogFile(filepath.Join(dir, names[len(names)-1]))
+	if err != nil {
+		return 0, err
+	}
+
+	var last uint64
+	for _, entry := range entries {
+		if entry.Seq > last {
+			last = entry.Seq
+		}
+	}
+	return last, nil
+}
+
+func readLogFile(path string) ([]LogEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []LogEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// sortedLogFiles returns the ndjson file names in dir ordered by date then
+// generation, e.g. 2026-07-24.ndjson, 2026-07-25.ndjson, 2026-07-25.1.ndjson.
+func sortedLogFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".ndjson") {
+			names = append(names, e.Name())
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		di, gi := parseLogFileName(names[i])
+		dj, gj := parseLogFileName(names[j])
+		if di != dj {
+			return di < dj
+		}
+		return gi < gj
+	})
+
+	return names, nil
+}
+
+// parseLogFileName splits "2026-07-25.3.ndjson" into ("2026-07-25", 3).
+func parseLogFileName(name string) (string, int) {
+	base := strings.TrimSuffix(name, ".ndjson")
+	parts := strings.Split(base, ".")
+	if len(parts) == 2 {
+		if gen, err := strconv.Atoi(parts[1]); err == nil {
+			return parts[0], gen
+		}
+	}
+	return base, 0
+}
+
+// retentionLoop periodically deletes log files whose modification time is
+// older than s.retention.
+func (s *FileLogStore) retentionLoop() {
+	ticker := time.NewTicker(1 * time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		s.pruneOldFiles()
+	}
+}
+
+func (s *FileLogStore) pruneOldFiles() {
+	cutoff := time.Now().Add(-s.retention)
+
+	// Snapshot the files currently open for writing so they're never
+	// unlinked out from under a deviceWriter still appending to them -
+	// doing so wouldn't fail the write, it would just silently drop every
+	// subsequent line into a file no longer reachable by name.
+	s.mu.Lock()
+	openPaths := make(map[string]bool, len(s.writers))
+	for _, w := range s.writers {
+		openPaths[w.file.Name()] = true
+	}
+	s.mu.Unlock()
+
+	deviceDirs, err := os.ReadDir(s.baseDir)
+	if err != nil {
+		return
+	}
+
+	for _, d := range deviceDirs {
+		if !d.IsDir() {
+			continue
+		}
+		devDir := filepath.Join(s.baseDir, d.Name())
+		files, err := os.ReadDir(devDir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			info, err := f.Info()
+			if err != nil || info.ModTime().After(cutoff) {
+				continue
+			}
+			path := filepath.Join(devDir, f.Name())
+			if openPaths[path] {
+				continue
+			}
+			if err := os.Remove(path); err == nil {
+				log.Printf("[LOGSTORE] Pruned expired log file: %s", path)
+			}
+		}
+	}
+}
+
+// mergeLogs combines on-disk history with the in-memory buffer, dropping
+// any history entries that overlap the start of the in-memory buffer so a
+// device reconnect doesn't duplicate entries that were persisted and kept
+// in memory.
+func mergeLogs(history, live []LogEntry) []LogEntry {
+	if len(live) == 0 {
+		return history
+	}
+	cutoff := live[0].Timestamp
+	merged := make([]LogEntry, 0, len(history)+len(live))
+	for _, entry := range history {
+		if entry.Timestamp.Before(cutoff) {
+			merged = append(merged, entry)
+		}
+	}
+	return append(merged, live...)
+}
+
+// parseSize parses human-friendly size strings like "50MB", "1GB", "512KB"
+// into a byte count. A bare number is treated as bytes.
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSpace(strings.ToUpper(s))
+	if s == "" {
+		return 0, nil
+	}
+
+	units := []struct {
+		suffix string
+		mult   int64
+	}{
+		{"GB", 1 << 30},
+		{"MB", 1 << 20},
+		{"KB", 1 << 10},
+		{"B", 1},
+	}
+
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			numStr := strings.TrimSuffix(s, u.suffix)
+			n, err := strconv.ParseFloat(numStr, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid size %q: %w", s, err)
+			}
+			return int64(n * float64(u.mult)), nil
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q: %w", s, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: MIT
+// Copyright © 2026 doxx.net. All Rights Reserved.
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseSize(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    int64
+		wantErr bool
+	}{
+		{"512", 512, false},
+		{"512B", 512, false},
+		{"50KB", 50 * 1 << 10, false},
+		{"10MB", 10 * 1 << 20, false},
+		{"1GB", 1 << 30, false},
+		{"", 0, false},
+		{"not-a-size", 0, true},
+	}
+
+	for _, c := range cases {
+		got, err := parseSize(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parseSize(%q): want error, got nil", c.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSize(%q): unexpected error: %v", c.in, err)
+		}
+		if got != c.want {
+			t.Errorf("parseSize(%q) = %d, want %d", c.in, got, c.want)
+		}
+	}
+}
+
+func TestLogFileNameAndParseRoundTrip(t *testing.T) {
+	cases := []struct {
+		date string
+		gen  int
+		name string
+	}{
+		{"2026-07-25", 0, "2026-07-25.ndjson"},
+		{"2026-07-25", 3, "2026-07-25.3.ndjson"},
+	}
+
+	for _, c := range cases {
+		if got := logFileName(c.date, c.gen); got != c.name {
+			t.Errorf("logFileName(%q, %d) = %q, want %q", c.date, c.gen, got, c.name)
+		}
+		date, gen := parseLogFileName(c.name)
+		if date != c.date || gen != c.gen {
+			t.Errorf("parseLogFileName(%q) = (%q, %d), want (%q, %d)", c.name, date, gen, c.date, c.gen)
+		}
+	}
+}
+
+func TestSortedLogFilesOrdersByDateThenGeneration(t *testing.T) {
+	dir := t.TempDir()
+	names := []string{
+		"2026-07-25.1.ndjson",
+		"2026-07-24.ndjson",
+		"2026-07-25.ndjson",
+		"not-a-log.txt",
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatalf("create %s: %v", name, err)
+		}
+	}
+
+	got, err := sortedLogFiles(dir)
+	if err != nil {
+		t.Fatalf("sortedLogFiles: %v", err)
+	}
+
+	want := []string{"2026-07-24.ndjson", "2026-07-25.ndjson", "2026-07-25.1.ndjson"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("sortedLogFiles = %v, want %v", got, want)
+	}
+}
+
+func TestMergeLogsDropsHistoryOverlappingLiveBuffer(t *testing.T) {
+	base := time.Date(2026, 7, 25, 12, 0, 0, 0, time.UTC)
+
+	history := []LogEntry{
+		{Timestamp: base, Message: "h0"},
+		{Timestamp: base.Add(1 * time.Second), Message: "h1"},
+		{Timestamp: base.Add(2 * time.Second), Message: "h2-overlaps-live"},
+	}
+	live := []LogEntry{
+		{Timestamp: base.Add(2 * time.Second), Message: "l0"},
+		{Timestamp: base.Add(3 * time.Second), Message: "l1"},
+	}
+
+	merged := mergeLogs(history, live)
+
+	want := []string{"h0", "h1", "l0", "l1"}
+	if len(merged) != len(want) {
+		t.Fatalf("mergeLogs returned %d entries, want %d: %v", len(merged), len(want), merged)
+	}
+	for i, entry := range merged {
+		if entry.Message != want[i] {
+			t.Errorf("merged[%d] = %q, want %q", i, entry.Message, want[i])
+		}
+	}
+}
+
+func TestMergeLogsWithNoLiveEntriesReturnsHistoryUnchanged(t *testing.T) {
+	history := []LogEntry{{Message: "h0"}, {Message: "h1"}}
+	merged := mergeLogs(history, nil)
+	if len(merged) != 2 {
+		t.Fatalf("mergeLogs with empty live = %d entries, want 2", len(merged))
+	}
+}
+
+func TestDeviceDirNameIsStableAndPathSafe(t *testing.T) {
+	malicious := "../../../etc/passwd"
+
+	dir := deviceDirName(malicious)
+	if strings.ContainsAny(dir, `/\`) || strings.Contains(dir, "..") {
+		t.Fatalf("deviceDirName(%q) = %q, contains path-traversal characters", malicious, dir)
+	}
+	if deviceDirName(malicious) != dir {
+		t.Fatalf("deviceDirName is not stable across calls for the same input")
+	}
+	if deviceDirName("other-device") == dir {
+		t.Fatalf("deviceDirName collided for distinct inputs")
+	}
+}
+
+func TestPruneOldFilesSkipsOpenWriter(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileLogStore(dir, 0, time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewFileLogStore: %v", err)
+	}
+
+	if err := store.Append("device-a", LogEntry{Timestamp: time.Now()}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+
+	// The file is open for append and brand new, but backdate its mtime so
+	// it looks expired to pruneOldFiles - the only thing that should save
+	// it from deletion is still being an open writer.
+	store.mu.Lock()
+	w := store.writers["device-a"]
+	path := w.file.Name()
+	store.mu.Unlock()
+
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatalf("backdate mtime: %v", err)
+	}
+
+	store.pruneOldFiles()
+
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("stat after prune: %v (file was deleted out from under the open writer)", err)
+	}
+}
@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: MIT
+// Copyright © 2026 doxx.net. All Rights Reserved.
+
+package main
+
+import "sync"
+
+// logRing is a fixed-capacity, overwrite-oldest ring buffer of a device's
+// most recent log entries. It replaces an unbounded slice so a chatty
+// device can't grow its session's memory use without limit, and stamps
+// every entry with a monotonically increasing sequence number so a
+// reconnecting dev client can resume from ?since_seq=N without duplicates.
+type logRing struct {
+	mu       sync.RWMutex
+	buf      []LogEntry
+	start    int // index of the oldest entry in buf
+	size     int // number of valid entries currently in buf
+	capacity int
+	nextSeq  uint64
+}
+
+// newLogRing creates a logRing holding at most capacity entries.
+func newLogRing(capacity int) *logRing {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &logRing{buf: make([]LogEntry, capacity), capacity: capacity}
+}
+
+// SeedSeq raises the ring's next sequence number to at least next, so a
+// freshly created ring can continue a device's sequence numbering across a
+// reconnect instead of restarting at 0 and stranding any ?since_seq=N
+// callers that haven't caught up yet. A no-op if next is behind where the
+// ring already is.
+func (r *logRing) SeedSeq(next uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if next > r.nextSeq {
+		r.nextSeq = next
+	}
+}
+
+// Append stamps entry with the next sequence number and stores it,
+// overwriting the oldest entry once the ring is full. It returns the
+// stamped entry for the caller to persist/broadcast.
+func (r *logRing) Append(entry LogEntry) LogEntry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.Seq = r.nextSeq
+	r.nextSeq++
+
+	idx := (r.start + r.size) % r.capacity
+	if r.size < r.capacity {
+		r.size++
+	} else {
+		r.start = (r.start + 1) % r.capacity
+	}
+	r.buf[idx] = entry
+
+	return entry
+}
+
+// Len reports how many entries are currently buffered.
+func (r *logRing) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.size
+}
+
+// All returns every buffered entry, oldest first.
+func (r *logRing) All() []LogEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]LogEntry, r.size)
+	for i := 0; i < r.size; i++ {
+		out[i] = r.buf[(r.start+i)%r.capacity]
+	}
+	return out
+}
+
+// Since returns buffered entries with Seq > sinceSeq, oldest first, so a
+// client that last saw seq N can resume with ?since_seq=N and get only
+// what's new.
+func (r *logRing) Since(sinceSeq uint64) []LogEntry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]LogEntry, 0, r.size)
+	for i := 0; i < r.size; i++ {
+		entry := r.buf[(r.start+i)%r.capacity]
+		if entry.Seq > sinceSeq {
+			out = append(out, entry)
+		}
+	}
+	return out
+}
@@ -0,0 +1,84 @@
+// SPDX-License-Identifier: MIT
+// Copyright © 2026 doxx.net. All Rights Reserved.
+
+package main
+
+import "testing"
+
+func TestLogRingAppendStampsIncreasingSeq(t *testing.T) {
+	r := newLogRing(4)
+
+	for i := 0; i < 3; i++ {
+		entry := r.Append(LogEntry{Message: "m"})
+		if entry.Seq != uint64(i) {
+			t.Fatalf("entry %d: got Seq %d, want %d", i, entry.Seq, i)
+		}
+	}
+	if got := r.Len(); got != 3 {
+		t.Fatalf("Len() = %d, want 3", got)
+	}
+}
+
+func TestLogRingEvictsOldestOnceFull(t *testing.T) {
+	r := newLogRing(3)
+	for i := 0; i < 5; i++ {
+		r.Append(LogEntry{Message: "m"})
+	}
+
+	if got := r.Len(); got != 3 {
+		t.Fatalf("Len() = %d, want 3 (capacity)", got)
+	}
+
+	all := r.All()
+	if len(all) != 3 {
+		t.Fatalf("All() returned %d entries, want 3", len(all))
+	}
+	// Oldest surviving entry should be Seq 2 (0 and 1 were evicted).
+	if all[0].Seq != 2 || all[2].Seq != 4 {
+		t.Fatalf("All() = %v, want seqs [2,3,4]", seqs(all))
+	}
+}
+
+func TestLogRingSinceReturnsOnlyNewer(t *testing.T) {
+	r := newLogRing(10)
+	for i := 0; i < 5; i++ {
+		r.Append(LogEntry{Message: "m"})
+	}
+
+	got := r.Since(2)
+	if len(got) != 2 {
+		t.Fatalf("Since(2) returned %d entries, want 2", len(got))
+	}
+	if got[0].Seq != 3 || got[1].Seq != 4 {
+		t.Fatalf("Since(2) = %v, want seqs [3,4]", seqs(got))
+	}
+
+	if got := r.Since(4); len(got) != 0 {
+		t.Fatalf("Since(4) returned %d entries, want 0", len(got))
+	}
+}
+
+func TestLogRingSeedSeqContinuesNumberingAcrossReconnect(t *testing.T) {
+	r := newLogRing(4)
+	r.SeedSeq(100)
+
+	entry := r.Append(LogEntry{Message: "m"})
+	if entry.Seq != 100 {
+		t.Fatalf("Seq after SeedSeq(100) = %d, want 100", entry.Seq)
+	}
+
+	// Seeding backwards must not rewind the sequence.
+	r.SeedSeq(50)
+	entry = r.Append(LogEntry{Message: "m"})
+	if entry.Seq != 101 {
+		t.Fatalf("Seq after SeedSeq(50) (a no-op) = %d, want 101", entry.Seq)
+	}
+}
+
+func seqs(entries []LogEntry) []uint64 {
+	out := make([]uint64, len(entries))
+	for i, e := range entries {
+		out[i] = e.Seq
+	}
+	return out
+}
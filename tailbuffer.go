@@ -0,0 +1,101 @@
+// SPDX-License-Identifier: MIT
+// Copyright © 2026 doxx.net. All Rights Reserved.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// tailDropPolicy controls what happens when a tail consumer's bounded
+// channel is full, i.e. a dev client isn't reading fast enough to keep up
+// with the device it's tailing.
+type tailDropPolicy int
+
+const (
+	tailDropOldest     tailDropPolicy = iota // evict the oldest buffered message to make room
+	tailDropNewest                           // discard the message that was about to be sent
+	tailDropDisconnect                       // close the consumer instead of letting it fall behind
+)
+
+func (p tailDropPolicy) String() string {
+	switch p {
+	case tailDropNewest:
+		return "newest"
+	case tailDropDisconnect:
+		return "disconnect"
+	default:
+		return "oldest"
+	}
+}
+
+// parseTailDropPolicy parses the --tail-drop flag value.
+func parseTailDropPolicy(s string) (tailDropPolicy, error) {
+	switch s {
+	case "oldest":
+		return tailDropOldest, nil
+	case "newest":
+		return tailDropNewest, nil
+	case "disconnect":
+		return tailDropDisconnect, nil
+	default:
+		return 0, fmt.Errorf("unknown --tail-drop %q (want oldest, newest, or disconnect)", s)
+	}
+}
+
+// enqueueTail delivers msg to consumer's bounded channel, applying the
+// server's drop policy if the consumer is too far behind to keep up. This
+// keeps broadcastToTail non-blocking, so one slow dev client can no longer
+// stall the ingest goroutine holding session.tailMu.
+func (s *Server) enqueueTail(session *Session, consumer *tailConsumer, msg []byte) {
+	select {
+	case consumer.msgs <- msg:
+		return
+	default:
+	}
+
+	switch s.tailDropPolicy {
+	case tailDropNewest:
+		// Leave the consumer's buffer as-is and drop msg.
+	case tailDropDisconnect:
+		consumer.conn.Close()
+	default: // tailDropOldest
+		evicted := false
+		select {
+		case <-consumer.msgs:
+			evicted = true
+		default:
+		}
+		select {
+		case consumer.msgs <- msg:
+			if !evicted {
+				return
+			}
+		default:
+		}
+	}
+
+	s.tailDropped.Add(1)
+	if s.metrics != nil {
+		s.metrics.TailDropped.WithLabelValues(session.DeviceHash, s.tailDropPolicy.String()).Inc()
+	}
+}
+
+// pumpTailConsumer drains consumer's channel and writes each message to its
+// WebSocket connection. Running this in its own goroutine per consumer means
+// a slow write blocks only this goroutine, never the ingest path.
+func (s *Server) pumpTailConsumer(consumer *tailConsumer) {
+	for msg := range consumer.msgs {
+		consumer.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+		if err := consumer.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+			if s.metrics != nil {
+				s.metrics.WSWriteErrors.Inc()
+			}
+			consumer.conn.Close()
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// SPDX-License-Identifier: MIT
+// Copyright © 2026 doxx.net. All Rights Reserved.
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestParseTailDropPolicy(t *testing.T) {
+	cases := []struct {
+		in      string
+		want    tailDropPolicy
+		wantErr bool
+	}{
+		{"oldest", tailDropOldest, false},
+		{"newest", tailDropNewest, false},
+		{"disconnect", tailDropDisconnect, false},
+		{"bogus", 0, true},
+		{"", 0, true},
+	}
+
+	for _, c := range cases {
+		got, err := parseTailDropPolicy(c.in)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("parseTailDropPolicy(%q): want error, got nil", c.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseTailDropPolicy(%q): unexpected error: %v", c.in, err)
+		}
+		if got != c.want {
+			t.Errorf("parseTailDropPolicy(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+// newTestTailConsumer dials a real WebSocket connection against an
+// httptest server so tailDropDisconnect's consumer.conn.Close() has a
+// genuine *websocket.Conn to operate on, same as in production.
+func newTestTailConsumer(t *testing.T, bufSize int) *tailConsumer {
+	t.Helper()
+
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		<-r.Context().Done()
+		conn.Close()
+	}))
+	t.Cleanup(srv.Close)
+
+	wsURL := "ws" + srv.URL[len("http"):]
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial test websocket: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return &tailConsumer{conn: conn, msgs: make(chan []byte, bufSize)}
+}
+
+func TestEnqueueTailOldestEvictsOldestWhenFull(t *testing.T) {
+	s := &Server{tailDropPolicy: tailDropOldest}
+	consumer := newTestTailConsumer(t, 2)
+	session := &Session{DeviceHash: "dev1"}
+
+	s.enqueueTail(session, consumer, []byte("a"))
+	s.enqueueTail(session, consumer, []byte("b"))
+	s.enqueueTail(session, consumer, []byte("c")) // channel full, should evict "a"
+
+	first := <-consumer.msgs
+	second := <-consumer.msgs
+	if string(first) != "b" || string(second) != "c" {
+		t.Fatalf("got messages %q, %q; want \"b\", \"c\"", first, second)
+	}
+}
+
+func TestEnqueueTailNewestDropsIncomingWhenFull(t *testing.T) {
+	s := &Server{tailDropPolicy: tailDropNewest}
+	consumer := newTestTailConsumer(t, 1)
+	session := &Session{DeviceHash: "dev1"}
+
+	s.enqueueTail(session, consumer, []byte("a"))
+	s.enqueueTail(session, consumer, []byte("b")) // channel full, "b" should be dropped
+
+	if got := <-consumer.msgs; string(got) != "a" {
+		t.Fatalf("got message %q, want \"a\" (b should have been dropped)", got)
+	}
+	if s.tailDropped.Load() != 1 {
+		t.Fatalf("tailDropped = %d, want 1", s.tailDropped.Load())
+	}
+}
+
+func TestEnqueueTailDisconnectClosesConsumerWhenFull(t *testing.T) {
+	s := &Server{tailDropPolicy: tailDropDisconnect}
+	consumer := newTestTailConsumer(t, 1)
+	session := &Session{DeviceHash: "dev1"}
+
+	s.enqueueTail(session, consumer, []byte("a"))
+	s.enqueueTail(session, consumer, []byte("b")) // channel full, should close the consumer
+
+	if err := consumer.conn.WriteMessage(websocket.TextMessage, []byte("ping")); err == nil {
+		t.Fatal("write succeeded on a connection enqueueTail should have closed")
+	}
+}